@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jadekler/nmod/pkg/nmod"
+)
+
+// dirsRecord is the -json record emitted by dirs, one per module.
+type dirsRecord struct {
+	Module string   `json:"module"`
+	Dirs   []string `json:"dirs"`
+}
+
+// dirs prints the directories belonging to each given module: every
+// directory in that module's tree containing at least one .go file, that
+// isn't shadowed by a nested go.mod - mirroring how "go list ./..." stops
+// descending once it reaches a nested module. Module arguments are resolved
+// via their declared module path, falling back to a local replace directive
+// pointing at them (see resolver); an argument ending in "/...." also
+// includes that module's nested modules (see resolveModuleArgs). If
+// jsonOutput is true, dirs emits one {"module", "dirs"} JSON record per
+// module instead of plain text.
+func dirs(mods []string, jsonOutput bool) error {
+	discovered, err := discoverModules()
+	if err != nil {
+		return err
+	}
+	res := newResolver(discovered)
+
+	resolved, err := resolveModuleArgs(res, mods)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, m := range resolved {
+		var ds []string
+		err := nmod.Walk([]nmod.Module{m}, func(_ nmod.Module, dir string) error {
+			ds = append(ds, dir)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			if err := enc.Encode(dirsRecord{Module: m.Path, Dirs: ds}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, d := range ds {
+			fmt.Println(d)
+		}
+	}
+
+	return nil
+}
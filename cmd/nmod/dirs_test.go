@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeGoFile writes a trivial .go file at dir/name, creating dir if
+// necessary.
+func writeGoFile(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("package p\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirs(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/parent")
+	writeGoFile(t, root, "p.go")
+	writeGoFile(t, filepath.Join(root, "pkg"), "p.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error { return dirs([]string{"example.com/parent"}, false) })
+	got := linesOf(out)
+	want := []string{root, filepath.Join(root, "pkg")}
+	sort.Strings(want)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("dirs: got %v, want %v", got, want)
+	}
+}
+
+func TestDirsJSON(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/parent")
+	writeGoFile(t, root, "p.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error { return dirs([]string{"example.com/parent"}, true) })
+
+	var rec dirsRecord
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Module != "example.com/parent" || len(rec.Dirs) != 1 || rec.Dirs[0] != root {
+		t.Errorf("dirs -json: got %+v, want {Module: example.com/parent, Dirs: [%s]}", rec, root)
+	}
+}
+
+// TestDirsReplaceOnly covers a module that's only reachable via another
+// in-repo module's replace directive - i.e. no go.mod in the repo declares
+// it under its own name.
+func TestDirsReplaceOnly(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(""+
+		"module example.com/parent\n\n"+
+		"go 1.18\n\n"+
+		"require example.com/foo v0.0.0\n\n"+
+		"replace example.com/foo => ./vendored-foo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeGoMod(t, filepath.Join(root, "vendored-foo"), "acme.local/vendored-foo")
+	writeGoFile(t, filepath.Join(root, "vendored-foo"), "p.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error { return dirs([]string{"example.com/foo"}, false) })
+	got := linesOf(out)
+	want := []string{filepath.Join(root, "vendored-foo")}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("dirs example.com/foo (replace-only): got %v, want %v", got, want)
+	}
+
+	rootOut := withStdout(t, func() error { return rootdirs([]string{"example.com/foo"}, false) })
+	rootGot := linesOf(rootOut)
+	if len(rootGot) != 1 || rootGot[0] != want[0] {
+		t.Errorf("rootdirs example.com/foo (replace-only): got %v, want %v", rootGot, want)
+	}
+}
+
+func TestRootdirsRecursiveSuffix(t *testing.T) {
+	root := nestedModulesLayout(t)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error { return rootdirs([]string{"example.com/outer/...."}, false) })
+	got := linesOf(out)
+	want := []string{
+		root,
+		filepath.Join(root, "sub", "middle"),
+		filepath.Join(root, "sub", "middle", "inner"),
+	}
+	sort.Strings(want)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("rootdirs example.com/outer/....: got %v, want %v", got, want)
+	}
+
+	out = withStdout(t, func() error { return rootdirs([]string{"example.com/outer/..."}, false) })
+	got = linesOf(out)
+	if len(got) != 1 || got[0] != root {
+		t.Errorf("rootdirs example.com/outer/...: got %v, want [%s]", got, root)
+	}
+}
+
+func TestDirsRecursiveSuffix(t *testing.T) {
+	root := nestedModulesLayout(t)
+	writeGoFile(t, root, "p.go")
+	writeGoFile(t, filepath.Join(root, "sub", "middle"), "p.go")
+	writeGoFile(t, filepath.Join(root, "sub", "middle", "inner"), "p.go")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error { return dirs([]string{"example.com/outer/...."}, false) })
+	got := linesOf(out)
+	want := []string{
+		root,
+		filepath.Join(root, "sub", "middle"),
+		filepath.Join(root, "sub", "middle", "inner"),
+	}
+	sort.Strings(want)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("dirs example.com/outer/....: got %v, want %v", got, want)
+	}
+
+	out = withStdout(t, func() error { return dirs([]string{"example.com/outer/..."}, false) })
+	got = linesOf(out)
+	if len(got) != 1 || got[0] != root {
+		t.Errorf("dirs example.com/outer/...: got %v, want [%s]", got, root)
+	}
+}
+
+func TestRootdirsAllAncestors(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	inner := filepath.Join(root, "inner")
+	writeGoMod(t, inner, "example.com/outer/inner")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error { return rootdirs([]string{"example.com/outer/inner"}, false) })
+	got := linesOf(out)
+	if len(got) != 1 || got[0] != inner {
+		t.Errorf("rootdirs example.com/outer/inner: got %v, want [%s]", got, inner)
+	}
+
+	allOut := withStdout(t, func() error { return rootdirs([]string{"example.com/outer/inner"}, true) })
+	allGot := linesOf(allOut)
+	want := []string{inner, root}
+	sort.Strings(want)
+	if len(allGot) != len(want) || allGot[0] != want[0] || allGot[1] != want[1] {
+		t.Errorf("rootdirs -all-ancestors example.com/outer/inner: got %v, want %v", allGot, want)
+	}
+}
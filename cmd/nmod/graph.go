@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jadekler/nmod/modfile"
+)
+
+// node is a module discovered in the repo.
+type node struct {
+	Path string `json:"path"`
+	Dir  string `json:"dir"`
+}
+
+// edge is an intra-repo dependency from one discovered module to another.
+type edge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Replace bool   `json:"replace"`
+}
+
+// graph prints the intra-repo module dependency graph in the given format:
+// "dot", "json", or "text".
+func graph(format string) error {
+	nodes, edges, err := buildGraph()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "text":
+		for _, e := range edges {
+			fmt.Printf("%s -> %s\n", e.From, e.To)
+		}
+	case "dot":
+		fmt.Println("digraph nmod {")
+		for _, n := range nodes {
+			fmt.Printf("\t%q;\n", n.Path)
+		}
+		for _, e := range edges {
+			fmt.Printf("\t%q -> %q;\n", e.From, e.To)
+		}
+		fmt.Println("}")
+	case "json":
+		out := struct {
+			Nodes []node `json:"nodes"`
+			Edges []edge `json:"edges"`
+		}{Nodes: nodes, Edges: edges}
+		return json.NewEncoder(os.Stdout).Encode(out)
+	default:
+		return fmt.Errorf("unknown -format %q: want dot, json, or text", format)
+	}
+
+	return nil
+}
+
+// buildGraph discovers every module in the repo (up and down from the
+// working directory) along with the intra-repo require/replace edges
+// between them: edges whose target resolves, via a replace directive or a
+// matching module path, to another discovered module. Nodes are sorted by
+// path; edges are sorted by (from, to).
+func buildGraph() ([]node, []edge, error) {
+	mods, err := discoverModules()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dirByPath := map[string]string{}
+	for _, m := range mods {
+		dirByPath[m.Path] = m.Dir
+	}
+
+	var nodes []node
+	for _, m := range mods {
+		nodes = append(nodes, node{Path: m.Path, Dir: m.Dir})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Path < nodes[j].Path })
+
+	var edges []edge
+	for _, m := range mods {
+		replaceByOld := map[string]modfile.Replace{}
+		for _, r := range m.File.Replaces() {
+			replaceByOld[r.Old.Path] = r
+		}
+
+		for _, req := range m.File.Requires() {
+			to, isReplace, ok := resolveRequire(req.Mod.Path, m.Dir, replaceByOld, dirByPath)
+			if !ok {
+				continue
+			}
+			edges = append(edges, edge{From: m.Path, To: to, Replace: isReplace})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+
+	return nodes, edges, nil
+}
+
+// resolveRequire reports whether a require directive's target path resolves
+// to another module discovered in the repo, either via a replace directive
+// (local filesystem or module+version) or because the required path itself
+// matches a discovered module's declared path.
+func resolveRequire(reqPath, ownerDir string, replaceByOld map[string]modfile.Replace, dirByPath map[string]string) (to string, isReplace, ok bool) {
+	if r, replaced := replaceByOld[reqPath]; replaced {
+		if r.New.Version != "" {
+			// Replacement to another module+version.
+			if _, ok := dirByPath[r.New.Path]; ok {
+				return r.New.Path, true, true
+			}
+			return "", false, false
+		}
+
+		// Local filesystem replacement: resolve to an absolute path and
+		// match it against a discovered module's directory.
+		abs, err := filepath.Abs(filepath.Join(ownerDir, r.New.Path))
+		if err != nil {
+			return "", false, false
+		}
+		for path, dir := range dirByPath {
+			if dir == abs {
+				return path, true, true
+			}
+		}
+		return "", false, false
+	}
+
+	if _, ok := dirByPath[reqPath]; ok {
+		return reqPath, false, true
+	}
+	return "", false, false
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildGraph(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(""+
+		"module example.com/outer\n\n"+
+		"go 1.18\n\n"+
+		"require example.com/outer/sub v0.0.0\n\n"+
+		"replace example.com/outer/sub => ./sub\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeGoMod(t, filepath.Join(root, "sub"), "example.com/outer/sub")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, edges, err := buildGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 2 {
+		t.Fatalf("got %d nodes, want 2: %v", len(nodes), nodes)
+	}
+	if len(edges) != 1 {
+		t.Fatalf("got %d edges, want 1: %v", len(edges), edges)
+	}
+	got := edges[0]
+	if got.From != "example.com/outer" || got.To != "example.com/outer/sub" || !got.Replace {
+		t.Errorf("got edge %+v, want {From: example.com/outer, To: example.com/outer/sub, Replace: true}", got)
+	}
+}
@@ -0,0 +1,614 @@
+/*
+nmod provides support for operations on nested modules.
+
+Usage: nmod <command> [args...]
+
+NOTE: nmod is built to be run at the root of a repository. It does NOT query
+for modules - it just scans directories in a straight line above, and
+recursively below, the working directory.
+
+A directory argument ending in "/...." (four dots) is treated like Go's
+"/..." wildcard, except that it also descends into nested modules. A plain
+"/..." (three dots) stops at the first go.mod it finds below the directory,
+matching Go's standard single-module semantics.
+
+The commands are:
+	modules			print the modules of the given dirs
+	rootdirs		print the root dirs of the given modules
+	dirs			print the dirs of the given modules
+	requires		print the require graph of the given modules
+	replaces		print the replace directives of the given modules
+	graph			print the intra-repo module dependency graph
+	toposort		print modules in intra-repo dependency order
+	vet, check		validate repo-wide invariants across nested modules
+
+modules:
+	nmod modules [-all-ancestors] [dirs...]
+
+modules prints the modules of the dirs if they're supplied. Dirs may be supplied
+as space separated arguments. If no dirs are supplied, modules prints the module
+of the current directory (if it exists) and all modules in directories
+recursively below the current directory. By default, a dir that sits inside
+a nested module's tree reports only the innermost enclosing module; with
+-all-ancestors, it reports every enclosing module, from innermost to
+outermost.
+
+rootdirs:
+	nmod rootdirs [-all-ancestors] [modules...]
+
+rootdirs prints the root directory of each given module. Modules may be
+supplied as space separated arguments. If no modules are supplied, rootdirs
+prints the root directories of every module discovered up and down from the
+current directory. A module argument is resolved against every discovered
+go.mod's declared module path, and - failing that - against every
+discovered go.mod's local replace directives, so "nmod rootdirs
+example.com/foo" finds foo's root dir even when foo is only referenced via
+a "replace example.com/foo => ../foo" elsewhere in the repo. By default,
+each argument reports only its innermost enclosing module's root dir; with
+-all-ancestors, it reports every enclosing module's root dir, from
+innermost to outermost. A module argument ending in "/...." also reports
+the root dirs of every module nested within it.
+
+dirs:
+	nmod dirs [-json] [modules...]
+
+dirs prints the directories belonging to the given modules. Modules may be
+supplied as space separated arguments. If no modules are supplied, dirs
+prints the directories belonging to every module discovered up and down
+from the current directory. Module arguments are resolved the same way as
+in rootdirs: by declared module path, falling back to a local replace
+directive pointing at the module, and a trailing "/...." also including the
+module's nested modules. With -json, dirs emits one {"module", "dirs"} JSON
+record per module instead of plain text.
+
+requires:
+	nmod requires [modules...]
+
+requires prints the require graph of the given modules, one "parent -> child
+version" line per require directive. Modules may be supplied as space
+separated arguments. If no modules are supplied, requires prints the require
+graph of every module discovered up and down from the current directory.
+
+replaces:
+	nmod replaces [modules...]
+
+replaces prints the active replace directives of the given modules, resolved
+to absolute paths for local filesystem replacements. Modules may be supplied
+as space separated arguments. If no modules are supplied, replaces prints the
+replace directives of every module discovered up and down from the current
+directory.
+
+graph:
+	nmod graph [-format=dot|json|text]
+
+graph prints the dependency graph between every module discovered up and
+down from the current directory. An edge is drawn from module A to module B
+when A requires B and that requirement resolves - via a replace directive or
+a matching module path - to a module discovered in the repo. -format selects
+the output: "dot" (Graphviz), "json"
+({"nodes":[{"path","dir"}],"edges":[{"from","to","replace"}]}), or "text"
+("A -> B" per line). Defaults to "text".
+
+toposort:
+	nmod toposort [-parallel] [modules...]
+
+toposort prints the given modules (or every module discovered up and down
+from the current directory, if none are given) in leaf-first dependency
+order, using the same intra-repo require/replace edges as graph: a module is
+printed only after every module it requires has already been printed. With
+-parallel, toposort instead prints newline-separated "waves" - groups of
+modules with no remaining intra-repo dependencies - each wave separated by a
+blank line, so a CI system can run each wave concurrently. A cycle among the
+given modules is reported as an error naming every module that could not be
+ordered: the modules on the cycle and any module that depends on one.
+
+vet, check:
+	nmod vet [-require-reachable]
+	nmod check [-require-reachable]
+
+vet (and its alias check) validates repo-wide invariants across every module
+discovered up and down from the current directory, printing one diagnostic
+per violation and exiting non-zero if any are found - the natural pre-commit
+or CI gate for a monorepo. Each diagnostic starts with the offending go.mod's
+path; only a missing replace directive also gives a line number, that of the
+require directive ("go.mod:line: message"). It checks that:
+every intra-repo require has a corresponding replace directive pointing at
+that module's actual directory; every module declares the same "go" version
+(or, if .nmod.yaml sets goVersion, that exact version); no two modules
+declare the same module path; and, with -require-reachable (or
+requireReachableFromRoot: true in .nmod.yaml), that every discovered module
+is reachable from the module enclosing the current directory via a chain of
+replace directives.
+*/
+package main // import "github.com/jadekler/nmod/cmd/nmod"
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jadekler/nmod/pkg/nmod"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: nmod <command> [args...]
+
+NOTE: nmod is built to be run at the root of a repository. It does NOT query
+for modules - it just scans directories in a straight line above, and
+recursively below, the working directory.
+
+A directory argument ending in "/...." (four dots) is treated like Go's
+"/..." wildcard, except that it also descends into nested modules. A plain
+"/..." (three dots) stops at the first go.mod it finds below the directory,
+matching Go's standard single-module semantics.
+
+The commands are:
+	modules			print the modules of the given dirs
+	rootdirs		print the root dirs of the given modules
+	dirs			print the dirs of the given modules
+	requires		print the require graph of the given modules
+	replaces		print the replace directives of the given modules
+	graph			print the intra-repo module dependency graph
+	toposort		print modules in intra-repo dependency order
+	vet, check		validate repo-wide invariants across nested modules
+
+modules:
+	nmod modules [-all-ancestors] [dirs...]
+
+modules prints the modules of the dirs if they're supplied. Dirs may be supplied
+as space separated arguments. If no dirs are supplied, modules prints the module
+of the current directory (if it exists) and all modules in directories
+recursively below the current directory. By default, a dir that sits inside
+a nested module's tree reports only the innermost enclosing module; with
+-all-ancestors, it reports every enclosing module, from innermost to
+outermost.
+
+rootdirs:
+	nmod rootdirs [-all-ancestors] [modules...]
+
+rootdirs prints the root directory of each given module. Modules may be
+supplied as space separated arguments. If no modules are supplied, rootdirs
+prints the root directories of every module discovered up and down from the
+current directory. A module argument is resolved against every discovered
+go.mod's declared module path, and - failing that - against every
+discovered go.mod's local replace directives, so "nmod rootdirs
+example.com/foo" finds foo's root dir even when foo is only referenced via
+a "replace example.com/foo => ../foo" elsewhere in the repo. By default,
+each argument reports only its innermost enclosing module's root dir; with
+-all-ancestors, it reports every enclosing module's root dir, from
+innermost to outermost. A module argument ending in "/...." also reports
+the root dirs of every module nested within it.
+
+dirs:
+	nmod dirs [-json] [modules...]
+
+dirs prints the directories belonging to the given modules. Modules may be
+supplied as space separated arguments. If no modules are supplied, dirs
+prints the directories belonging to every module discovered up and down
+from the current directory. Module arguments are resolved the same way as
+in rootdirs: by declared module path, falling back to a local replace
+directive pointing at the module, and a trailing "/...." also including the
+module's nested modules. With -json, dirs emits one {"module", "dirs"} JSON
+record per module instead of plain text.
+
+requires:
+	nmod requires [modules...]
+
+requires prints the require graph of the given modules, one "parent -> child
+version" line per require directive. Modules may be supplied as space
+separated arguments. If no modules are supplied, requires prints the require
+graph of every module discovered up and down from the current directory.
+
+replaces:
+	nmod replaces [modules...]
+
+replaces prints the active replace directives of the given modules, resolved
+to absolute paths for local filesystem replacements. Modules may be supplied
+as space separated arguments. If no modules are supplied, replaces prints the
+replace directives of every module discovered up and down from the current
+directory.
+
+graph:
+	nmod graph [-format=dot|json|text]
+
+graph prints the dependency graph between every module discovered up and
+down from the current directory. An edge is drawn from module A to module B
+when A requires B and that requirement resolves - via a replace directive or
+a matching module path - to a module discovered in the repo. -format selects
+the output: "dot" (Graphviz), "json"
+({"nodes":[{"path","dir"}],"edges":[{"from","to","replace"}]}), or "text"
+("A -> B" per line). Defaults to "text".
+
+toposort:
+	nmod toposort [-parallel] [modules...]
+
+toposort prints the given modules (or every module discovered up and down
+from the current directory, if none are given) in leaf-first dependency
+order, using the same intra-repo require/replace edges as graph: a module is
+printed only after every module it requires has already been printed. With
+-parallel, toposort instead prints newline-separated "waves" - groups of
+modules with no remaining intra-repo dependencies - each wave separated by a
+blank line, so a CI system can run each wave concurrently. A cycle among the
+given modules is reported as an error naming every module that could not be
+ordered: the modules on the cycle and any module that depends on one.
+
+vet, check:
+	nmod vet [-require-reachable]
+	nmod check [-require-reachable]
+
+vet (and its alias check) validates repo-wide invariants across every module
+discovered up and down from the current directory, printing one diagnostic
+per violation and exiting non-zero if any are found - the natural pre-commit
+or CI gate for a monorepo. Each diagnostic starts with the offending go.mod's
+path; only a missing replace directive also gives a line number, that of the
+require directive ("go.mod:line: message"). It checks that:
+every intra-repo require has a corresponding replace directive pointing at
+that module's actual directory; every module declares the same "go" version
+(or, if .nmod.yaml sets goVersion, that exact version); no two modules
+declare the same module path; and, with -require-reachable (or
+requireReachableFromRoot: true in .nmod.yaml), that every discovered module
+is reachable from the module enclosing the current directory via a chain of
+replace directives.
+`)
+	os.Exit(2)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+	}
+
+	if err := nmodCmd(args[0], args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "nmod: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func nmodCmd(cmd string, args []string) error {
+	switch cmd {
+	case "modules":
+		fs := flag.NewFlagSet("modules", flag.ExitOnError)
+		allAncestors := fs.Bool("all-ancestors", false, "for each dir, list every enclosing module instead of just the innermost")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		dirs := fs.Args()
+		if len(dirs) == 0 {
+			mods, err := discoverModules()
+			if err != nil {
+				return err
+			}
+			for _, m := range mods {
+				dirs = append(dirs, m.Dir)
+			}
+		}
+		return modules(dirs, *allAncestors)
+	case "requires":
+		return requires(args)
+	case "replaces":
+		return replaces(args)
+	case "graph":
+		fs := flag.NewFlagSet("graph", flag.ExitOnError)
+		format := fs.String("format", "text", "output format: dot, json, or text")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return graph(*format)
+	case "toposort":
+		fs := flag.NewFlagSet("toposort", flag.ExitOnError)
+		parallel := fs.Bool("parallel", false, "print newline-separated waves of modules with no remaining intra-repo dependencies, instead of one module per line")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		return toposort(fs.Args(), *parallel)
+	case "dirs":
+		fs := flag.NewFlagSet("dirs", flag.ExitOnError)
+		jsonOutput := fs.Bool("json", false, `emit {"module", "dirs"} JSON records instead of plain text`)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		mods := fs.Args()
+		if len(mods) == 0 {
+			var err error
+			mods, err = defaultModules()
+			if err != nil {
+				return err
+			}
+		}
+		return dirs(mods, *jsonOutput)
+	case "rootdirs":
+		fs := flag.NewFlagSet("rootdirs", flag.ExitOnError)
+		allAncestors := fs.Bool("all-ancestors", false, "for each module, list every enclosing module's root dir instead of just the innermost")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		mods := fs.Args()
+		if len(mods) == 0 {
+			var err error
+			mods, err = defaultModules()
+			if err != nil {
+				return err
+			}
+		}
+		return rootdirs(mods, *allAncestors)
+	case "vet", "check":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		requireReachable := fs.Bool("require-reachable", false, "also require every discovered module to be reachable from the enclosing module via a chain of replace directives")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		violated, err := vet(*requireReachable)
+		if err != nil {
+			return err
+		}
+		if violated {
+			os.Exit(1)
+		}
+		return nil
+	case "help":
+		usage()
+	default:
+		usage()
+	}
+
+	return nil
+}
+
+// discoverModules returns every module discovered up and down from the
+// working directory: recursively below it, crossing into nested modules,
+// and through the nearest enclosing module above it, if any.
+func discoverModules() ([]nmod.Module, error) {
+	mods, err := nmod.Discover(".", nmod.Options{CrossModule: true})
+	if err != nil {
+		return nil, err
+	}
+
+	ancestors, err := nmod.FindAllEnclosing(".")
+	if err != nil {
+		return nil, err
+	}
+	if len(ancestors) > 0 {
+		mods = appendUniqueModule(mods, ancestors[0])
+	}
+
+	return mods, nil
+}
+
+// appendUniqueModule appends m to mods unless a module with the same go.mod
+// is already present.
+func appendUniqueModule(mods []nmod.Module, m nmod.Module) []nmod.Module {
+	for _, existing := range mods {
+		if existing.GoMod == m.GoMod {
+			return mods
+		}
+	}
+	return append(mods, m)
+}
+
+// defaultModules returns the module paths of every module discovered up and
+// down from the working directory.
+func defaultModules() ([]string, error) {
+	mods, err := discoverModules()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, m := range mods {
+		paths = append(paths, m.Path)
+	}
+	return paths, nil
+}
+
+// addUnique appends s to *out unless it's already in seen, recording it in
+// seen either way.
+func addUnique(seen map[string]struct{}, out *[]string, s string) {
+	if _, ok := seen[s]; ok {
+		return
+	}
+	seen[s] = struct{}{}
+	*out = append(*out, s)
+}
+
+// contains reports whether ss contains s.
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func modules(dirs []string, allAncestors bool) error {
+	seen := map[string]struct{}{}
+	var paths []string
+
+	for _, d := range dirs {
+		root, crossModule := splitRecursive(d)
+
+		// Pessimistically assume user didn't provide an absolute path - convert
+		// every path into an absolute path.
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+
+		if crossModule {
+			// Walk down from the given root, including nested modules.
+			mods, err := nmod.Discover(absRoot, nmod.Options{CrossModule: true})
+			if err != nil {
+				return err
+			}
+			for _, m := range mods {
+				addUnique(seen, &paths, m.Path)
+			}
+			continue
+		}
+
+		if allAncestors {
+			mods, err := nmod.FindAllEnclosing(absRoot)
+			if err != nil {
+				return err
+			}
+			if len(mods) == 0 {
+				return fmt.Errorf("%s doesn't have a go.mod, nor do any of the directories above it", absRoot)
+			}
+			for _, m := range mods {
+				addUnique(seen, &paths, m.Path)
+			}
+			continue
+		}
+
+		// Go up from specified directory until we see a go.mod.
+		m, err := nmod.FindEnclosing(absRoot)
+		if err != nil {
+			return err
+		}
+		addUnique(seen, &paths, m.Path)
+	}
+
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+
+	return nil
+}
+
+func rootdirs(mods []string, allAncestors bool) error {
+	discovered, err := discoverModules()
+	if err != nil {
+		return err
+	}
+	res := newResolver(discovered)
+
+	resolved, err := resolveModuleArgs(res, mods)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]struct{}{}
+	var out []string
+	for _, m := range resolved {
+		if allAncestors {
+			ancestors, err := nmod.FindAllEnclosing(m.Dir)
+			if err != nil {
+				return err
+			}
+			for _, a := range ancestors {
+				addUnique(seen, &out, a.Dir)
+			}
+			continue
+		}
+
+		addUnique(seen, &out, m.Dir)
+	}
+
+	sort.Strings(out)
+	for _, d := range out {
+		fmt.Println(d)
+	}
+
+	return nil
+}
+
+// resolver resolves a module path argument to the module whose code
+// actually lives there: either the module that declares that exact path, or
+// - if none does - the module that some other discovered module's local
+// replace directive points at.
+type resolver struct {
+	byDeclaredPath map[string]nmod.Module
+	byReplacedPath map[string]nmod.Module
+}
+
+// newResolver builds a resolver from every module discovered in the repo.
+func newResolver(mods []nmod.Module) resolver {
+	byDir := map[string]nmod.Module{}
+	for _, m := range mods {
+		byDir[m.Dir] = m
+	}
+
+	r := resolver{
+		byDeclaredPath: map[string]nmod.Module{},
+		byReplacedPath: map[string]nmod.Module{},
+	}
+	for _, m := range mods {
+		r.byDeclaredPath[m.Path] = m
+
+		for _, rep := range m.File.Replaces() {
+			if rep.New.Version != "" {
+				// Replacement to another module+version, not a local path.
+				continue
+			}
+			abs, err := filepath.Abs(filepath.Join(m.Dir, rep.New.Path))
+			if err != nil {
+				continue
+			}
+			if target, ok := byDir[abs]; ok {
+				r.byReplacedPath[rep.Old.Path] = target
+			}
+		}
+	}
+	return r
+}
+
+// resolve looks up modPath among modules declared in the repo, falling back
+// to modules that some other discovered module's replace directive points
+// at.
+func (r resolver) resolve(modPath string) (nmod.Module, bool) {
+	if m, ok := r.byDeclaredPath[modPath]; ok {
+		return m, true
+	}
+	m, ok := r.byReplacedPath[modPath]
+	return m, ok
+}
+
+// splitRecursive splits a directory argument into its root path and whether
+// it requested cross-module recursion via a trailing "/....".
+func splitRecursive(path string) (root string, crossModule bool) {
+	if strings.HasSuffix(path, "/....") {
+		return strings.TrimSuffix(path, "/...."), true
+	}
+	if strings.HasSuffix(path, "/...") {
+		return strings.TrimSuffix(path, "/..."), false
+	}
+	return path, false
+}
+
+// resolveModuleArgs resolves each mod argument to the module(s) it denotes,
+// via res. An argument ending in "/...." also includes every module nested
+// within the resolved module's tree, matching the "/...." convention
+// documented in the package doc; a trailing "/..." is stripped with no
+// further effect, since a dirs/rootdirs argument already denotes a single
+// module.
+func resolveModuleArgs(res resolver, mods []string) ([]nmod.Module, error) {
+	var out []nmod.Module
+	for _, arg := range mods {
+		modPath, crossModule := splitRecursive(arg)
+
+		m, ok := res.resolve(modPath)
+		if !ok {
+			return nil, fmt.Errorf("%s: no go.mod in the repo declares this module, and no replace directive points at it", modPath)
+		}
+
+		if !crossModule {
+			out = append(out, m)
+			continue
+		}
+
+		nested, err := nmod.Discover(m.Dir, nmod.Options{CrossModule: true})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, nested...)
+	}
+	return out, nil
+}
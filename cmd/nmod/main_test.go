@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeGoMod writes a minimal go.mod declaring the given module path,
+// creating dir if necessary.
+func writeGoMod(t *testing.T, dir, module string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "module " + module + "\n\ngo 1.18\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// nestedModulesLayout lays out three nested modules under a temp root:
+// root, root/sub/middle, and root/sub/middle/inner.
+func nestedModulesLayout(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	writeGoMod(t, filepath.Join(root, "sub", "middle"), "example.com/outer/sub/middle")
+	writeGoMod(t, filepath.Join(root, "sub", "middle", "inner"), "example.com/outer/sub/middle/inner")
+	return root
+}
+
+func TestSplitRecursive(t *testing.T) {
+	tests := []struct {
+		path      string
+		wantRoot  string
+		wantCross bool
+	}{
+		{"foo/bar", "foo/bar", false},
+		{"foo/bar/...", "foo/bar", false},
+		{"foo/bar/....", "foo/bar", true},
+	}
+	for _, tt := range tests {
+		root, cross := splitRecursive(tt.path)
+		if root != tt.wantRoot || cross != tt.wantCross {
+			t.Errorf("splitRecursive(%q) = (%q, %v), want (%q, %v)", tt.path, root, cross, tt.wantRoot, tt.wantCross)
+		}
+	}
+}
+
+// withStdout redirects os.Stdout for the duration of fn and returns what was
+// written to it.
+func withStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+
+	fnErr := fn()
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if fnErr != nil {
+		t.Fatal(fnErr)
+	}
+	return buf.String()
+}
+
+func linesOf(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestModulesRecursiveSuffix(t *testing.T) {
+	root := nestedModulesLayout(t)
+
+	out := withStdout(t, func() error { return modules([]string{root + "/...."}, false) })
+	got := linesOf(out)
+	want := []string{
+		"example.com/outer",
+		"example.com/outer/sub/middle",
+		"example.com/outer/sub/middle/inner",
+	}
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("modules %s/....: got %v, want %v", root, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("modules %s/....: got %v, want %v", root, got, want)
+			break
+		}
+	}
+
+	out = withStdout(t, func() error { return modules([]string{root + "/..."}, false) })
+	got = linesOf(out)
+	if len(got) != 1 || got[0] != "example.com/outer" {
+		t.Errorf("modules %s/...: got %v, want [example.com/outer]", root, got)
+	}
+}
+
+func TestModulesAllAncestors(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	inner := filepath.Join(root, "inner")
+	writeGoMod(t, inner, "example.com/outer/inner")
+
+	out := withStdout(t, func() error { return modules([]string{inner}, true) })
+	got := linesOf(out)
+	want := []string{"example.com/outer", "example.com/outer/inner"}
+	sort.Strings(want)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("modules -all-ancestors %s: got %v, want %v", inner, got, want)
+	}
+}
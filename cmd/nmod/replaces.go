@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// replaces prints the active replace directives for each given module (or
+// every module discovered up and down from the working directory if none
+// are given), resolving local filesystem replacements to absolute paths.
+// Replace directives pointing at a module+version, rather than a local
+// path, are printed as "old => new version".
+func replaces(mods []string) error {
+	discovered, err := discoverModules()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range discovered {
+		if len(mods) > 0 && !contains(mods, m.Path) {
+			continue
+		}
+		for _, r := range m.File.Replaces() {
+			if r.New.Version != "" {
+				fmt.Printf("%s => %s %s\n", r.Old.Path, r.New.Path, r.New.Version)
+				continue
+			}
+			absPath, err := filepath.Abs(filepath.Join(m.Dir, r.New.Path))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s => %s\n", r.Old.Path, absPath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import "fmt"
+
+// requires prints the require graph as one "parent -> child version" line
+// per require directive, within the repo or not, for each given module (or every
+// module discovered up and down from the working directory if none are
+// given).
+func requires(mods []string) error {
+	discovered, err := discoverModules()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range discovered {
+		if len(mods) > 0 && !contains(mods, m.Path) {
+			continue
+		}
+		for _, r := range m.File.Requires() {
+			fmt.Printf("%s -> %s %s\n", m.Path, r.Mod.Path, r.Mod.Version)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// toposort prints modules in leaf-first dependency order: a module is
+// printed only after every module it requires (within the repo) has
+// already been printed. If mods is non-empty, the graph is restricted to
+// just those modules. If parallel is true, toposort instead prints
+// newline-separated "waves" - groups of modules with no remaining
+// intra-repo dependencies - separated by a blank line, so each wave can be
+// built or tested concurrently.
+func toposort(mods []string, parallel bool) error {
+	nodes, edges, err := buildGraph()
+	if err != nil {
+		return err
+	}
+	if len(mods) > 0 {
+		nodes, edges = filterGraph(nodes, edges, mods)
+	}
+
+	waves, err := waves(nodes, edges)
+	if err != nil {
+		return err
+	}
+
+	for i, wave := range waves {
+		if parallel && i > 0 {
+			fmt.Println()
+		}
+		for _, path := range wave {
+			fmt.Println(path)
+		}
+	}
+
+	return nil
+}
+
+// waves groups nodes into leaf-first waves: wave 0 holds every node with no
+// intra-repo dependencies, wave 1 holds every node whose dependencies are
+// all in wave 0, and so on. If a cycle prevents any further progress, it
+// returns an error naming every module not yet placed in a wave: the
+// modules on the cycle and any module that depends on one.
+func waves(nodes []node, edges []edge) ([][]string, error) {
+	deps := map[string]map[string]struct{}{}
+	for _, n := range nodes {
+		deps[n.Path] = map[string]struct{}{}
+	}
+	for _, e := range edges {
+		deps[e.From][e.To] = struct{}{}
+	}
+
+	remaining := map[string]struct{}{}
+	for _, n := range nodes {
+		remaining[n.Path] = struct{}{}
+	}
+
+	var out [][]string
+	for len(remaining) > 0 {
+		var wave []string
+		for path := range remaining {
+			ready := true
+			for dep := range deps[path] {
+				if _, ok := remaining[dep]; ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, path)
+			}
+		}
+		if len(wave) == 0 {
+			var cycle []string
+			for path := range remaining {
+				cycle = append(cycle, path)
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("cycle detected among modules: %v", cycle)
+		}
+
+		sort.Strings(wave)
+		out = append(out, wave)
+		for _, path := range wave {
+			delete(remaining, path)
+		}
+	}
+
+	return out, nil
+}
+
+// filterGraph restricts nodes and edges to just the given module paths.
+func filterGraph(nodes []node, edges []edge, mods []string) ([]node, []edge) {
+	keep := map[string]struct{}{}
+	for _, m := range mods {
+		keep[m] = struct{}{}
+	}
+
+	var filteredNodes []node
+	for _, n := range nodes {
+		if _, ok := keep[n.Path]; ok {
+			filteredNodes = append(filteredNodes, n)
+		}
+	}
+
+	var filteredEdges []edge
+	for _, e := range edges {
+		_, fromOK := keep[e.From]
+		_, toOK := keep[e.To]
+		if fromOK && toOK {
+			filteredEdges = append(filteredEdges, e)
+		}
+	}
+
+	return filteredNodes, filteredEdges
+}
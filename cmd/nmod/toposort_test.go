@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWaves(t *testing.T) {
+	nodes := []node{{Path: "a"}, {Path: "b"}, {Path: "c"}}
+	edges := []edge{
+		{From: "a", To: "b"},
+		{From: "b", To: "c"},
+	}
+
+	got, err := waves(nodes, edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"c"}, {"b"}, {"a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("waves() = %v, want %v", got, want)
+	}
+}
+
+func TestWavesCycle(t *testing.T) {
+	nodes := []node{{Path: "a"}, {Path: "b"}}
+	edges := []edge{
+		{From: "a", To: "b"},
+		{From: "b", To: "a"},
+	}
+
+	if _, err := waves(nodes, edges); err == nil {
+		t.Fatal("waves() with a cycle: got nil error, want non-nil")
+	}
+}
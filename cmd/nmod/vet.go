@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jadekler/nmod/pkg/nmod"
+	"gopkg.in/yaml.v3"
+)
+
+// vetConfig is the optional repo-root .nmod.yaml configuration for vet.
+type vetConfig struct {
+	// GoVersion, if set, is the exact "go" directive version every
+	// discovered module must declare. If unset, vet instead requires every
+	// module to agree with whichever module it sees first.
+	GoVersion string `yaml:"goVersion"`
+
+	// RequireReachableFromRoot, if true, is equivalent to always passing
+	// -require-reachable to "nmod vet".
+	RequireReachableFromRoot bool `yaml:"requireReachableFromRoot"`
+}
+
+// loadVetConfig reads .nmod.yaml from the working directory, returning the
+// zero vetConfig if it doesn't exist.
+func loadVetConfig() (vetConfig, error) {
+	data, err := os.ReadFile(".nmod.yaml")
+	if os.IsNotExist(err) {
+		return vetConfig{}, nil
+	}
+	if err != nil {
+		return vetConfig{}, err
+	}
+
+	var cfg vetConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return vetConfig{}, fmt.Errorf(".nmod.yaml: %w", err)
+	}
+	return cfg, nil
+}
+
+// vet validates repo-wide invariants across every module discovered up and
+// down from the working directory, printing one diagnostic per violation:
+// "file:line: message" for a missing replace directive, where line is that
+// of the require, and "file: message" for every other check. It reports
+// whether any violations were found.
+func vet(requireReachable bool) (bool, error) {
+	cfg, err := loadVetConfig()
+	if err != nil {
+		return false, err
+	}
+	requireReachable = requireReachable || cfg.RequireReachableFromRoot
+
+	mods, err := discoverModules()
+	if err != nil {
+		return false, err
+	}
+
+	var diags []string
+	diags = append(diags, checkReplaceCoverage(mods)...)
+	diags = append(diags, checkGoVersion(mods, cfg)...)
+	diags = append(diags, checkDuplicatePaths(mods)...)
+	if requireReachable {
+		reachDiags, err := checkReachability(mods)
+		if err != nil {
+			return false, err
+		}
+		diags = append(diags, reachDiags...)
+	}
+
+	sort.Strings(diags)
+	for _, d := range diags {
+		fmt.Println(d)
+	}
+
+	return len(diags) > 0, nil
+}
+
+// checkReplaceCoverage reports every require directive targeting another
+// module discovered in the repo that doesn't have a corresponding replace
+// directive pointing at that module's actual directory.
+func checkReplaceCoverage(mods []nmod.Module) []string {
+	dirByPath := map[string]string{}
+	for _, m := range mods {
+		dirByPath[m.Path] = m.Dir
+	}
+
+	var diags []string
+	for _, m := range mods {
+		replacedDirs := map[string]string{}
+		for _, r := range m.File.Replaces() {
+			if r.New.Version != "" {
+				continue
+			}
+			abs, err := filepath.Abs(filepath.Join(m.Dir, r.New.Path))
+			if err != nil {
+				continue
+			}
+			replacedDirs[r.Old.Path] = abs
+		}
+
+		for _, req := range m.File.Requires() {
+			targetDir, isIntraRepo := dirByPath[req.Mod.Path]
+			if !isIntraRepo {
+				continue
+			}
+			if gotDir, ok := replacedDirs[req.Mod.Path]; ok && gotDir == targetDir {
+				continue
+			}
+			diags = append(diags, fmt.Sprintf("%s:%d: requires %s, which is a module in this repo, but has no replace directive pointing at %s", m.GoMod, req.Pos.Line, req.Mod.Path, targetDir))
+		}
+	}
+	return diags
+}
+
+// checkGoVersion reports every module whose "go" directive disagrees with
+// either cfg.GoVersion (if set) or the first declared version seen.
+func checkGoVersion(mods []nmod.Module, cfg vetConfig) []string {
+	want := cfg.GoVersion
+	if want == "" {
+		for _, m := range mods {
+			if v := m.File.GoVersion(); v != "" {
+				want = v
+				break
+			}
+		}
+	}
+	if want == "" {
+		return nil
+	}
+
+	var diags []string
+	for _, m := range mods {
+		if v := m.File.GoVersion(); v != "" && v != want {
+			diags = append(diags, fmt.Sprintf("%s: declares go %s, want go %s", m.GoMod, v, want))
+		}
+	}
+	return diags
+}
+
+// checkDuplicatePaths reports any module path declared by more than one
+// discovered go.mod.
+func checkDuplicatePaths(mods []nmod.Module) []string {
+	goModsByPath := map[string][]string{}
+	for _, m := range mods {
+		goModsByPath[m.Path] = append(goModsByPath[m.Path], m.GoMod)
+	}
+
+	var paths []string
+	for p := range goModsByPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var diags []string
+	for _, p := range paths {
+		goMods := goModsByPath[p]
+		if len(goMods) < 2 {
+			continue
+		}
+		sort.Strings(goMods)
+		diags = append(diags, fmt.Sprintf("%s: module path %q is also declared by %s", goMods[0], p, strings.Join(goMods[1:], ", ")))
+	}
+	return diags
+}
+
+// checkReachability reports every discovered module that isn't reachable
+// from the module enclosing the working directory via a chain of replace
+// directives (local or module+version, as resolved by buildGraph).
+func checkReachability(mods []nmod.Module) ([]string, error) {
+	root, err := nmod.FindEnclosing(".")
+	if err != nil {
+		return nil, fmt.Errorf("-require-reachable: %w", err)
+	}
+
+	_, edges, err := buildGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	adj := map[string][]string{}
+	for _, e := range edges {
+		if !e.Replace {
+			continue
+		}
+		adj[e.From] = append(adj[e.From], e.To)
+	}
+
+	reached := map[string]struct{}{root.Path: {}}
+	queue := []string{root.Path}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range adj[cur] {
+			if _, ok := reached[next]; ok {
+				continue
+			}
+			reached[next] = struct{}{}
+			queue = append(queue, next)
+		}
+	}
+
+	var diags []string
+	for _, m := range mods {
+		if _, ok := reached[m.Path]; !ok {
+			diags = append(diags, fmt.Sprintf("%s: module %q is not reachable from %s via a chain of replace directives", m.GoMod, m.Path, root.Path))
+		}
+	}
+	sort.Strings(diags)
+	return diags, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVetReplaceCoverage(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(""+
+		"module example.com/outer\n\n"+
+		"go 1.18\n\n"+
+		"require example.com/outer/sub v0.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGoMod(t, filepath.Join(root, "sub"), "example.com/outer/sub")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error {
+		violated, err := vet(false)
+		if err != nil {
+			return err
+		}
+		if !violated {
+			t.Error("vet: got no violations, want a missing-replace violation")
+		}
+		return nil
+	})
+	if !strings.Contains(out, "no replace directive pointing at") {
+		t.Errorf("vet: got %q, want a diagnostic about the missing replace directive", out)
+	}
+}
+
+func TestVetGoVersionMismatch(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", "go.mod"), []byte(""+
+		"module example.com/outer/sub\n\ngo 1.20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error {
+		violated, err := vet(false)
+		if err != nil {
+			return err
+		}
+		if !violated {
+			t.Error("vet: got no violations, want a go-version mismatch violation")
+		}
+		return nil
+	})
+	if !strings.Contains(out, "declares go 1.20, want go 1.18") {
+		t.Errorf("vet: got %q, want a go-version mismatch diagnostic", out)
+	}
+}
+
+func TestVetDuplicatePath(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	writeGoMod(t, filepath.Join(root, "dup"), "example.com/outer")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	out := withStdout(t, func() error {
+		violated, err := vet(false)
+		if err != nil {
+			return err
+		}
+		if !violated {
+			t.Error("vet: got no violations, want a duplicate-module-path violation")
+		}
+		return nil
+	})
+	if !strings.Contains(out, `module path "example.com/outer" is also declared by`) {
+		t.Errorf("vet: got %q, want a duplicate-module-path diagnostic", out)
+	}
+}
+
+func TestVetClean(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(""+
+		"module example.com/outer\n\n"+
+		"go 1.18\n\n"+
+		"require example.com/outer/sub v0.0.0\n\n"+
+		"replace example.com/outer/sub => ./sub\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGoMod(t, filepath.Join(root, "sub"), "example.com/outer/sub")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	violated, err := vet(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if violated {
+		t.Error("vet: got violations on a clean tree, want none")
+	}
+}
+
+func TestVetRequireReachable(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(""+
+		"module example.com/outer\n\ngo 1.18\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeGoMod(t, filepath.Join(root, "orphan"), "example.com/orphan")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	violated, err := vet(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if violated {
+		t.Error("vet without -require-reachable: got violations, want none")
+	}
+
+	out := withStdout(t, func() error {
+		violated, err := vet(true)
+		if err != nil {
+			return err
+		}
+		if !violated {
+			t.Error("vet -require-reachable: got no violations, want an unreachable-module violation")
+		}
+		return nil
+	})
+	if !strings.Contains(out, "is not reachable from example.com/outer") {
+		t.Errorf("vet -require-reachable: got %q, want an unreachable-module diagnostic", out)
+	}
+}
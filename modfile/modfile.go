@@ -0,0 +1,121 @@
+// Package modfile parses go.mod files using golang.org/x/mod/modfile and
+// exposes the pieces nmod needs to reason about nested modules: the module
+// path, the declared Go version, and the require/replace/exclude
+// directives.
+package modfile
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	xmodfile "golang.org/x/mod/modfile"
+)
+
+// ModFile is a parsed go.mod file.
+type ModFile struct {
+	// Path is the filesystem path to the go.mod file.
+	Path string
+
+	file *xmodfile.File
+}
+
+// Parse reads and parses the go.mod file at path.
+func Parse(path string) (*ModFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := xmodfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &ModFile{Path: path, file: f}, nil
+}
+
+// Module returns the module path declared by the module directive, or "" if
+// the go.mod has none.
+func (m *ModFile) Module() string {
+	if m.file.Module == nil {
+		return ""
+	}
+	return m.file.Module.Mod.Path
+}
+
+// GoVersion returns the version in the go directive, or "" if absent.
+func (m *ModFile) GoVersion() string {
+	if m.file.Go == nil {
+		return ""
+	}
+	return m.file.Go.Version
+}
+
+// Module is a module path and version, as used on either side of a require,
+// replace, or exclude directive.
+type Module struct {
+	Path    string
+	Version string
+}
+
+// Position is the location of a directive within a go.mod file.
+type Position struct {
+	// Line is the 1-based line number of the directive.
+	Line int
+}
+
+// Require is a require directive.
+type Require struct {
+	Mod      Module
+	Indirect bool
+	Pos      Position
+}
+
+// Requires returns the require directives declared in the go.mod file.
+func (m *ModFile) Requires() []Require {
+	var out []Require
+	for _, r := range m.file.Require {
+		out = append(out, Require{
+			Mod:      Module{Path: r.Mod.Path, Version: r.Mod.Version},
+			Indirect: r.Indirect,
+			Pos:      positionOf(r.Syntax),
+		})
+	}
+	return out
+}
+
+// Replace is a replace directive. New.Version is empty when the replacement
+// is a local filesystem path rather than a module+version.
+type Replace struct {
+	Old, New Module
+	Pos      Position
+}
+
+// Replaces returns the replace directives declared in the go.mod file.
+func (m *ModFile) Replaces() []Replace {
+	var out []Replace
+	for _, r := range m.file.Replace {
+		out = append(out, Replace{
+			Old: Module{Path: r.Old.Path, Version: r.Old.Version},
+			New: Module{Path: r.New.Path, Version: r.New.Version},
+			Pos: positionOf(r.Syntax),
+		})
+	}
+	return out
+}
+
+// positionOf extracts the starting line of a directive's syntax node, or the
+// zero Position if line is unavailable.
+func positionOf(line *xmodfile.Line) Position {
+	if line == nil {
+		return Position{}
+	}
+	return Position{Line: line.Start.Line}
+}
+
+// Excludes returns the exclude directives declared in the go.mod file.
+func (m *ModFile) Excludes() []Module {
+	var out []Module
+	for _, e := range m.file.Exclude {
+		out = append(out, Module{Path: e.Mod.Path, Version: e.Mod.Version})
+	}
+	return out
+}
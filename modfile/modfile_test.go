@@ -0,0 +1,93 @@
+package modfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// write writes content to a go.mod file in a fresh temp directory and
+// returns its path.
+func write(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseQuotedPath(t *testing.T) {
+	path := write(t, "module \"example.com/weird module\"\n\ngo 1.18\n")
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.Module(), "example.com/weird module"; got != want {
+		t.Errorf("Module() = %q, want %q", got, want)
+	}
+}
+
+func TestParseIndirectComment(t *testing.T) {
+	path := write(t, "module example.com/foo\n\ngo 1.18\n\nrequire example.com/bar v1.0.0 // indirect\n")
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := f.Requires()
+	if len(reqs) != 1 || reqs[0].Mod.Path != "example.com/bar" || !reqs[0].Indirect {
+		t.Errorf("Requires() = %+v, want one indirect require of example.com/bar", reqs)
+	}
+}
+
+func TestParseBlockForm(t *testing.T) {
+	path := write(t, "module example.com/foo\n\ngo 1.18\n\nrequire (\n\texample.com/bar v1.0.0\n\texample.com/baz v1.1.0\n)\n\nreplace (\n\texample.com/bar => ../bar\n)\n")
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.Module(), "example.com/foo"; got != want {
+		t.Errorf("Module() = %q, want %q", got, want)
+	}
+
+	reqs := f.Requires()
+	if len(reqs) != 2 {
+		t.Fatalf("Requires() = %+v, want 2 requires", reqs)
+	}
+	if reqs[0].Mod.Path != "example.com/bar" || reqs[1].Mod.Path != "example.com/baz" {
+		t.Errorf("Requires() = %+v, want example.com/bar then example.com/baz", reqs)
+	}
+
+	reps := f.Replaces()
+	if len(reps) != 1 || reps[0].Old.Path != "example.com/bar" || reps[0].New.Path != "../bar" {
+		t.Errorf("Replaces() = %+v, want one replace of example.com/bar => ../bar", reps)
+	}
+}
+
+func TestParseCRLF(t *testing.T) {
+	content := strings.Join([]string{
+		"module example.com/foo",
+		"",
+		"go 1.18",
+		"",
+		"require example.com/bar v1.0.0",
+		"",
+	}, "\r\n")
+	path := write(t, content)
+
+	f, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.Module(), "example.com/foo"; got != want {
+		t.Errorf("Module() = %q, want %q", got, want)
+	}
+	if got, want := f.GoVersion(), "1.18"; got != want {
+		t.Errorf("GoVersion() = %q, want %q", got, want)
+	}
+	reqs := f.Requires()
+	if len(reqs) != 1 || reqs[0].Mod.Path != "example.com/bar" {
+		t.Errorf("Requires() = %+v, want one require of example.com/bar", reqs)
+	}
+}
@@ -0,0 +1,239 @@
+// Package nmod discovers and walks nested Go modules: modules declared by
+// go.mod files nested within a repository, as opposed to modules fetched as
+// dependencies.
+package nmod
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jadekler/nmod/modfile"
+)
+
+// Module is a single discovered go.mod file.
+type Module struct {
+	// Path is the module path declared by the module directive.
+	Path string
+	// Dir is the absolute directory containing the go.mod file.
+	Dir string
+	// GoMod is the absolute path to the go.mod file itself.
+	GoMod string
+	// File is the parsed go.mod file.
+	File *modfile.ModFile
+}
+
+// Options configures Discover.
+type Options struct {
+	// CrossModule, if true, descends into nested modules when walking down
+	// from root. If false, descent stops as soon as a nested go.mod is
+	// found below root, mirroring Go's "./..." semantics, which doesn't
+	// cross into nested modules.
+	CrossModule bool
+}
+
+// Discover walks the directory tree rooted at root looking for go.mod
+// files, parsing each one into a Module.
+func Discover(root string, opts Options) ([]Module, error) {
+	modFiles, err := modFilesRecursivelyDown(root, opts.CrossModule)
+	if err != nil {
+		return nil, err
+	}
+	return newModules(modFiles)
+}
+
+// FindEnclosing returns the module declared by the nearest go.mod at or
+// above dir. It returns an error if no go.mod is found.
+func FindEnclosing(dir string) (Module, error) {
+	mods, err := FindAllEnclosing(dir)
+	if err != nil {
+		return Module{}, err
+	}
+	if len(mods) == 0 {
+		return Module{}, fmt.Errorf("%s doesn't have a go.mod, nor do any of the directories above it", dir)
+	}
+	return mods[0], nil
+}
+
+// FindAllEnclosing returns every module declared by a go.mod at or above
+// dir, innermost first. A repo can nest a module inside another module - a
+// layout the Go toolchain explicitly permits (see golang.org/cl/148517,
+// "module mode without a main module"). If none are found, it returns a nil
+// slice and a nil error.
+func FindAllEnclosing(dir string) ([]Module, error) {
+	modFiles, err := searchUpwardsForModFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return newModules(modFiles)
+}
+
+// Walk calls fn once for every directory in each module's tree containing
+// at least one .go file, stopping descent at any nested module's boundary.
+func Walk(mods []Module, fn func(Module, string) error) error {
+	for _, m := range mods {
+		dirs, err := PackageDirs(m.Dir)
+		if err != nil {
+			return err
+		}
+		for _, d := range dirs {
+			if err := fn(m, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newModules(goModPaths []string) ([]Module, error) {
+	var mods []Module
+	for _, f := range goModPaths {
+		mf, err := modfile.Parse(f)
+		if err != nil {
+			return nil, err
+		}
+		if mf.Module() == "" {
+			return nil, fmt.Errorf("%s doesn't seem to have a module declaration", f)
+		}
+		dir, err := filepath.Abs(filepath.Dir(f))
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, Module{
+			Path:  mf.Module(),
+			Dir:   dir,
+			GoMod: filepath.Join(dir, "go.mod"),
+			File:  mf,
+		})
+	}
+	return mods, nil
+}
+
+// modFilesRecursivelyDown walks the directory tree rooted at root looking
+// for go.mod files. If crossModule is false, it stops descending as soon as
+// it finds a go.mod below root, mirroring Go's "./..." semantics, which
+// doesn't cross into nested modules. If crossModule is true, every nested
+// go.mod is found, however deeply nested.
+func modFilesRecursivelyDown(root string, crossModule bool) ([]string, error) {
+	var modFiles []string
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		modFile := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(modFile); err == nil {
+			if dir == root || crossModule {
+				modFiles = append(modFiles, modFile)
+			}
+			if dir != root && !crossModule {
+				// A nested module's go.mod isn't part of root's own module;
+				// stop descending without reporting it.
+				return nil
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				if err := walk(filepath.Join(dir, entry.Name())); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return modFiles, nil
+}
+
+// searchUpwardsForModFiles searches startDir and every directory above it
+// for go.mod files, returning every one found, innermost first. If none are
+// found, it returns a nil slice and a nil error.
+func searchUpwardsForModFiles(startDir string) ([]string, error) {
+	var modFiles []string
+
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		modFile := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(modFile); err == nil {
+			modFiles = append(modFiles, modFile)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return modFiles, nil
+}
+
+// PackageDirs returns every directory under root (inclusive) containing at
+// least one .go file, not descending into directories shadowed by a nested
+// go.mod. The result is sorted and deduplicated.
+func PackageDirs(root string) ([]string, error) {
+	seen := map[string]struct{}{}
+	var out []string
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		if dir != root {
+			if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+				// Shadowed by a nested module; don't descend into it.
+				return nil
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		hasGoFile := false
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
+				hasGoFile = true
+			}
+		}
+		if hasGoFile {
+			if _, ok := seen[dir]; !ok {
+				seen[dir] = struct{}{}
+				out = append(out, dir)
+			}
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				if err := walk(filepath.Join(dir, e.Name())); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(out)
+	return out, nil
+}
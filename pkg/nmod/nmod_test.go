@@ -0,0 +1,141 @@
+package nmod
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeGoMod writes a minimal go.mod declaring the given module path,
+// creating dir if necessary.
+func writeGoMod(t *testing.T, dir, module string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "module " + module + "\n\ngo 1.18\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeGoFile writes a trivial .go file at dir/name, creating dir if
+// necessary.
+func writeGoFile(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("package p\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// nestedModulesLayout lays out three nested modules under a temp root:
+// root, root/sub/middle, and root/sub/middle/inner.
+func nestedModulesLayout(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	writeGoMod(t, filepath.Join(root, "sub", "middle"), "example.com/outer/sub/middle")
+	writeGoMod(t, filepath.Join(root, "sub", "middle", "inner"), "example.com/outer/sub/middle/inner")
+	return root
+}
+
+func TestDiscover(t *testing.T) {
+	root := nestedModulesLayout(t)
+
+	cross, err := Discover(root, Options{CrossModule: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cross) != 3 {
+		t.Errorf("cross-module Discover: got %d modules, want 3: %v", len(cross), cross)
+	}
+
+	single, err := Discover(root, Options{CrossModule: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single) != 1 || single[0].Path != "example.com/outer" {
+		t.Errorf("single-module Discover: got %v, want [example.com/outer]", single)
+	}
+}
+
+func TestFindAllEnclosing(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/outer")
+	inner := filepath.Join(root, "inner")
+	writeGoMod(t, inner, "example.com/outer/inner")
+
+	got, err := FindAllEnclosing(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Path != "example.com/outer/inner" || got[1].Path != "example.com/outer" {
+		t.Errorf("FindAllEnclosing(%q) = %v, want [example.com/outer/inner, example.com/outer] (innermost first)", inner, got)
+	}
+
+	enclosing, err := FindEnclosing(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enclosing.Path != "example.com/outer/inner" {
+		t.Errorf("FindEnclosing(%q) = %+v, want Path example.com/outer/inner", inner, enclosing)
+	}
+}
+
+func TestFindEnclosingNotFound(t *testing.T) {
+	root := t.TempDir()
+	if _, err := FindEnclosing(root); err == nil {
+		t.Fatal("FindEnclosing with no go.mod anywhere above: got nil error, want non-nil")
+	}
+}
+
+func TestPackageDirsShadowing(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/parent")
+	writeGoFile(t, root, "p.go")
+	writeGoFile(t, filepath.Join(root, "pkg"), "p.go")
+
+	nested := filepath.Join(root, "nested")
+	writeGoMod(t, nested, "example.com/parent/nested")
+	writeGoFile(t, nested, "p.go")
+
+	got, err := PackageDirs(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{root, filepath.Join(root, "pkg")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PackageDirs(%q) = %v, want %v (nested module's dirs must be shadowed)", root, got, want)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, root, "example.com/parent")
+	writeGoFile(t, root, "p.go")
+	writeGoFile(t, filepath.Join(root, "pkg"), "p.go")
+
+	mods, err := Discover(root, Options{CrossModule: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	err = Walk(mods, func(m Module, dir string) error {
+		got = append(got, dir)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{root, filepath.Join(root, "pkg")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk visited %v, want %v", got, want)
+	}
+}